@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Babaiii07/Go_Language/internal/ai"
+)
+
+var client *ai.Client
+
+func askHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("stream") == "true" {
+		streamResponse(w, r, query)
+		return
+	}
+
+	response, err := client.Generate(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"response": response})
+}
+
+func askStreamHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+		return
+	}
+	streamResponse(w, r, query)
+}
+
+// streamResponse forwards each Gemini delta to the client as its own SSE
+// event, flushing after every write, and emits a final "done" event once the
+// upstream stream ends or the client disconnects.
+func streamResponse(w http.ResponseWriter, r *http.Request, query string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	deltas, err := client.GenerateStream(ctx, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	for {
+		select {
+		case delta, open := <-deltas:
+			if !open {
+				fmt.Fprint(w, "event: done\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+			if delta.Err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", delta.Err.Error())
+				flusher.Flush()
+				return
+			}
+			payload, _ := json.Marshal(map[string]string{"text": delta.Text})
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func main() {
+	c, err := ai.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client = c
+
+	http.HandleFunc("/ask", askHandler)
+	http.HandleFunc("/ask/stream", askStreamHandler)
+	fmt.Println("Server running on port 8080...")
+	http.ListenAndServe("127.0.0.1:8080", nil)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Babaiii07/Go_Language/internal/ai"
+)
+
+const aiHistoryContextLines = 10
+
+var (
+	aiClient *ai.Client
+	dryRun   bool
+)
+
+// initAI parses --dry-run and connects the shared Gemini client; a missing
+// GEMINI_API_KEY only disables the "ai" builtin, it doesn't stop the shell
+// from starting.
+func initAI() {
+	flag.BoolVar(&dryRun, "dry-run", false, "never execute commands suggested by the ai builtin")
+	flag.Parse()
+
+	if c, err := ai.NewClient(); err == nil {
+		aiClient = c
+	}
+}
+
+func aiHandler(cmd *CMD) {
+	if len(cmd.Args) < 2 {
+		fmt.Fprintln(cmd.Stderr, "ai: missing query")
+		return
+	}
+	runAIQuery(cmd, strings.Join(cmd.Args[1:], " "))
+}
+
+func runAIQuery(cmd *CMD, query string) {
+	if aiClient == nil {
+		fmt.Fprintln(cmd.Stderr, "ai: GEMINI_API_KEY not set")
+		return
+	}
+
+	reply, err := aiClient.Generate(context.Background(), buildAIPrompt(query))
+	if err != nil {
+		fmt.Fprintln(cmd.Stderr, "ai:", err)
+		return
+	}
+	fmt.Fprintln(cmd.Stdout, reply)
+
+	for _, block := range extractShBlocks(reply) {
+		offerToRun(cmd, block)
+	}
+}
+
+// buildAIPrompt includes pwd and the last aiHistoryContextLines commands so
+// the model's answer is aware of where the user is and what they just ran.
+func buildAIPrompt(query string) string {
+	var b strings.Builder
+
+	cwd, _ := os.Getwd()
+	fmt.Fprintf(&b, "You are helping inside a shell session.\npwd: %s\n", cwd)
+
+	if recent := recentHistory(aiHistoryContextLines); len(recent) > 0 {
+		b.WriteString("Recent commands:\n")
+		for _, line := range recent {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nQuestion: %s\n", query)
+	return b.String()
+}
+
+func recentHistory(n int) []string {
+	if len(historyList) <= n {
+		return historyList
+	}
+	return historyList[len(historyList)-n:]
+}
+
+// extractShBlocks pulls the contents out of fenced ```sh / ```bash / ```shell
+// code blocks in a Gemini reply.
+func extractShBlocks(reply string) []string {
+	var blocks []string
+	var current strings.Builder
+	inBlock := false
+
+	for _, line := range strings.Split(reply, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !inBlock {
+			if strings.HasPrefix(trimmed, "```sh") || strings.HasPrefix(trimmed, "```bash") || strings.HasPrefix(trimmed, "```shell") {
+				inBlock = true
+				current.Reset()
+			}
+			continue
+		}
+		if trimmed == "```" {
+			inBlock = false
+			if current.Len() > 0 {
+				blocks = append(blocks, strings.TrimRight(current.String(), "\n"))
+			}
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	return blocks
+}
+
+// offerToRun prompts [R]un / [E]dit / [C]ancel for a suggested command block
+// and, if accepted, feeds it back through the normal parse/execute path.
+func offerToRun(cmd *CMD, block string) {
+	if dryRun {
+		fmt.Fprintf(cmd.Stdout, "\nSuggested command (not run, --dry-run set):\n%s\n", block)
+		return
+	}
+
+	fmt.Fprintf(cmd.Stdout, "\n%s\n[R]un / [E]dit / [C]ancel? ", block)
+
+	reader := bufio.NewReader(cmd.Stdin)
+	answer, _ := reader.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "e", "edit":
+		fmt.Fprint(cmd.Stdout, "edit> ")
+		edited, _ := reader.ReadString('\n')
+		runSuggestedCommand(strings.TrimSpace(edited))
+	case "r", "run":
+		runSuggestedCommand(block)
+	default:
+		fmt.Fprintln(cmd.Stdout, "cancelled")
+	}
+}
+
+// runSuggestedCommand feeds an accepted ai suggestion through the same
+// parse/redirect/pipeline path a typed command goes through.
+func runSuggestedCommand(line string) {
+	if line == "" {
+		return
+	}
+
+	fields := parseCommand(line)
+	if len(fields) == 0 {
+		return
+	}
+	appendHistory(line)
+
+	rawStages, background := splitPipeline(fields)
+	var stages []*Stage
+	for _, rf := range rawStages {
+		sfields, stdoutFile, stderrFile, stdoutAppend, stderrAppend := processRedirectionOperators(rf)
+		if len(sfields) == 0 {
+			return
+		}
+		stages = append(stages, &Stage{
+			Fields:       sfields,
+			StdoutFile:   stdoutFile,
+			StderrFile:   stderrFile,
+			StdoutAppend: stdoutAppend,
+			StderrAppend: stderrAppend,
+		})
+	}
+	executePipeline(stages, background, line)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func exitHandler(cmd *CMD) {
+	os.Exit(0)
+}
+
+func echoHandler(cmd *CMD) {
+	fmt.Fprintln(cmd.Stdout, strings.Join(cmd.Args[1:], " "))
+}
+
+func typeHandler(cmd *CMD) {
+	if len(cmd.Args) < 2 {
+		fmt.Fprintln(cmd.Stdout, "type: missing argument")
+		return
+	}
+	name := cmd.Args[1]
+	builtinNames := map[string]bool{
+		"echo": true,
+		"exit": true,
+		"type": true,
+		"pwd":  true,
+		"cd":   true,
+	}
+
+	if builtinNames[name] {
+		fmt.Fprintln(cmd.Stdout, name+" is a shell builtin")
+	} else if path, err := exec.LookPath(name); err == nil {
+		fmt.Fprintln(cmd.Stdout, name+" is "+path)
+	} else {
+		fmt.Fprintln(cmd.Stdout, name+": not found")
+	}
+}
+
+func pwdHandler(cmd *CMD) {
+	cwd, _ := os.Getwd()
+	fmt.Fprintln(cmd.Stdout, cwd)
+}
+
+func cdHandler(cmd *CMD) {
+	if len(cmd.Args) < 2 {
+		fmt.Fprintln(cmd.Stdout, "cd: missing argument")
+		return
+	}
+
+	dir := cmd.Args[1]
+	switch {
+	case dir == "~":
+		dir = os.Getenv("HOME")
+	case strings.HasPrefix(dir, "~/"):
+		dir = os.Getenv("HOME") + dir[1:]
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintf(cmd.Stdout, "cd: %s: No such file or directory\n", dir)
+	}
+}
+
+func lsHandler(cmd *CMD) {
+	dir := "."
+	if len(cmd.Args) > 1 {
+		dir = cmd.Args[1]
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Fprintf(cmd.Stdout, "ls: cannot access '%s': %v\n", dir, err)
+		return
+	}
+
+	for _, file := range files {
+		fmt.Fprintln(cmd.Stdout, file.Name())
+	}
+}
+
+func catHandler(cmd *CMD) {
+	if len(cmd.Args) < 2 {
+		io.Copy(cmd.Stdout, cmd.Stdin)
+		return
+	}
+
+	for _, file := range cmd.Args[1:] {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(cmd.Stdout, "cat: %s: %v\n", file, err)
+			continue
+		}
+		fmt.Fprint(cmd.Stdout, string(content))
+	}
+}
+
+func cpHandler(cmd *CMD) {
+	args := cmd.Args[1:]
+	recursive := false
+	if len(args) > 0 && args[0] == "-r" {
+		recursive = true
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		fmt.Fprintln(cmd.Stdout, "cp: missing file operand")
+		return
+	}
+
+	src, dst := args[0], args[1]
+
+	info, err := os.Stat(src)
+	if err != nil {
+		fmt.Fprintf(cmd.Stdout, "cp: cannot stat '%s': %v\n", src, err)
+		return
+	}
+
+	if info.IsDir() {
+		if !recursive {
+			fmt.Fprintf(cmd.Stdout, "cp: -r not specified; omitting directory '%s'\n", src)
+			return
+		}
+		if err := copyDir(src, dst); err != nil {
+			fmt.Fprintf(cmd.Stdout, "cp: %v\n", err)
+		}
+		return
+	}
+
+	if err := copyFile(src, dst, info.Mode()); err != nil {
+		fmt.Fprintf(cmd.Stdout, "cp: %v\n", err)
+	}
+}
+
+// copyFile streams src to dst via io.Copy rather than buffering the whole
+// file in memory, and carries over src's permission bits.
+func copyFile(src, dst string, mode fs.FileMode) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("cannot open '%s': %w", src, err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("cannot create '%s': %w", dst, err)
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return fmt.Errorf("'%s' -> '%s': %w", src, dst, err)
+	}
+
+	return os.Chmod(dst, mode)
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func mvHandler(cmd *CMD) {
+	if len(cmd.Args) < 3 {
+		fmt.Fprintln(cmd.Stdout, "mv: missing file operand")
+		return
+	}
+
+	src := cmd.Args[1]
+	dst := cmd.Args[2]
+
+	err := os.Rename(src, dst)
+	if err == nil {
+		return
+	}
+	if !isCrossDeviceError(err) {
+		fmt.Fprintf(cmd.Stdout, "mv: cannot move '%s' to '%s': %v\n", src, dst, err)
+		return
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		fmt.Fprintf(cmd.Stdout, "mv: cannot stat '%s': %v\n", src, err)
+		return
+	}
+	if err := copyFile(src, dst, info.Mode()); err != nil {
+		fmt.Fprintf(cmd.Stdout, "mv: %v\n", err)
+		return
+	}
+	if err := os.Remove(src); err != nil {
+		fmt.Fprintf(cmd.Stdout, "mv: cannot remove '%s': %v\n", src, err)
+	}
+}
+
+func rmHandler(cmd *CMD) {
+	if len(cmd.Args) < 2 {
+		fmt.Fprintln(cmd.Stdout, "rm: missing operand")
+		return
+	}
+
+	for _, file := range cmd.Args[1:] {
+		err := os.Remove(file)
+		if err != nil {
+			fmt.Fprintf(cmd.Stdout, "rm: cannot remove '%s': %v\n", file, err)
+		}
+	}
+}
+
+func mkdirHandler(cmd *CMD) {
+	if len(cmd.Args) < 2 {
+		fmt.Fprintln(cmd.Stdout, "mkdir: missing operand")
+		return
+	}
+
+	for _, dir := range cmd.Args[1:] {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			fmt.Fprintf(cmd.Stdout, "mkdir: cannot create directory '%s': %v\n", dir, err)
+		}
+	}
+}
+
+func rmdirHandler(cmd *CMD) {
+	if len(cmd.Args) < 2 {
+		fmt.Fprintln(cmd.Stdout, "rmdir: missing operand")
+		return
+	}
+
+	for _, dir := range cmd.Args[1:] {
+		err := os.Remove(dir)
+		if err != nil {
+			fmt.Fprintf(cmd.Stdout, "rmdir: failed to remove '%s': %v\n", dir, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// clearHandler resets the terminal via the ANSI "clear + reset scrollback"
+// sequence instead of shelling out, since cmd /c cls doesn't exist here.
+func clearHandler(cmd *CMD) {
+	fmt.Fprint(cmd.Stdout, "\x1b[H\x1b[2J\x1b[3J")
+}
+
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package main
+
+import "os/exec"
+
+func clearHandler(cmd *CMD) {
+	c := exec.Command("cmd", "/c", "cls")
+	c.Stdout = cmd.Stdout
+	c.Run()
+}
+
+// Windows doesn't surface cross-volume renames as a distinct error the way
+// Unix reports EXDEV, so there's nothing to detect and fall back from here.
+func isCrossDeviceError(err error) bool {
+	return false
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const historyCap = 1000
+
+var historyList []string
+
+// historyCursor is the in-progress position of Up/Down browsing: a value of
+// len(historyList) means "not browsing, editing a fresh line".
+var historyCursor int
+
+// historyStash holds whatever the user had typed before they started
+// browsing, so pressing Down back past the newest entry restores it.
+var historyStash string
+
+func historyFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".shx_history"
+	}
+	return filepath.Join(home, ".shx_history")
+}
+
+// loadHistory reads ~/.shx_history, dedupes consecutive repeats, and caps the
+// result at historyCap entries.
+func loadHistory() []string {
+	data, err := os.ReadFile(historyFilePath())
+	if err != nil {
+		return nil
+	}
+
+	var deduped []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if len(deduped) > 0 && deduped[len(deduped)-1] == line {
+			continue
+		}
+		deduped = append(deduped, line)
+	}
+
+	if len(deduped) > historyCap {
+		deduped = deduped[len(deduped)-historyCap:]
+	}
+	return deduped
+}
+
+// appendHistory records a newly accepted line in memory and on disk, skipping
+// it if it repeats the immediately preceding entry.
+func appendHistory(line string) {
+	if line == "" {
+		return
+	}
+	if len(historyList) > 0 && historyList[len(historyList)-1] == line {
+		return
+	}
+
+	historyList = append(historyList, line)
+	if len(historyList) > historyCap {
+		historyList = historyList[len(historyList)-historyCap:]
+	}
+
+	f, err := os.OpenFile(historyFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// resetHistoryBrowsing is called at the start of each prompt so Up/Down
+// navigation always starts from the newest entry.
+func resetHistoryBrowsing() {
+	historyCursor = len(historyList)
+	historyStash = ""
+}
+
+func historyUp(current string) string {
+	if historyCursor == len(historyList) {
+		historyStash = current
+	}
+	if historyCursor > 0 {
+		historyCursor--
+	}
+	if historyCursor < len(historyList) {
+		return historyList[historyCursor]
+	}
+	return current
+}
+
+func historyDown() string {
+	if historyCursor < len(historyList) {
+		historyCursor++
+	}
+	if historyCursor >= len(historyList) {
+		return historyStash
+	}
+	return historyList[historyCursor]
+}
+
+// expandHistoryReferences replaces standalone "!N" references with the text
+// of history entry N (1-indexed), leaving everything else untouched.
+func expandHistoryReferences(command string) string {
+	if !strings.Contains(command, "!") {
+		return command
+	}
+
+	var out strings.Builder
+	i := 0
+	for i < len(command) {
+		c := command[i]
+		if c == '!' && i+1 < len(command) && command[i+1] >= '0' && command[i+1] <= '9' {
+			j := i + 1
+			for j < len(command) && command[j] >= '0' && command[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(command[i+1 : j]); err == nil && n >= 1 && n <= len(historyList) {
+				out.WriteString(historyList[n-1])
+				i = j
+				continue
+			}
+		}
+		out.WriteByte(c)
+		i++
+	}
+	return out.String()
+}
+
+func historyHandler(cmd *CMD) {
+	if len(cmd.Args) > 1 && cmd.Args[1] == "-c" {
+		historyList = nil
+		resetHistoryBrowsing()
+		if err := os.Truncate(historyFilePath(), 0); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintln(cmd.Stderr, "history:", err)
+		}
+		return
+	}
+
+	for i, line := range historyList {
+		fmt.Fprintf(cmd.Stdout, "%5d  %s\n", i+1, line)
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Stage is one command in a pipeline, with its own redirection targets.
+type Stage struct {
+	Fields       []string
+	StdoutFile   string
+	StderrFile   string
+	StdoutAppend bool
+	StderrAppend bool
+}
+
+// splitPipeline breaks a token stream into pipeline stages at unquoted "|"
+// tokens, and strips a trailing "&" into a background flag.
+func splitPipeline(fields []string) ([][]string, bool) {
+	background := false
+	if n := len(fields); n > 0 && fields[n-1] == "&" {
+		background = true
+		fields = fields[:n-1]
+	}
+
+	var stages [][]string
+	var current []string
+	for _, f := range fields {
+		if f == "|" {
+			stages = append(stages, current)
+			current = nil
+		} else {
+			current = append(current, f)
+		}
+	}
+	stages = append(stages, current)
+	return stages, background
+}
+
+func isBuiltinStage(name string) bool {
+	_, ok := builtins[name]
+	return ok
+}
+
+// JobStatus is the run state of a backgrounded or stopped pipeline.
+type JobStatus int
+
+const (
+	JobRunning JobStatus = iota
+	JobStopped
+	JobDone
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobRunning:
+		return "Running"
+	case JobStopped:
+		return "Stopped"
+	default:
+		return "Done"
+	}
+}
+
+// Job tracks one pipeline's processes so jobs/fg/bg/wait and signal
+// forwarding can find it again after it stops being the foreground pipeline.
+type Job struct {
+	ID      int
+	Command string
+	PGID    int
+	Cmds    []*exec.Cmd
+	Status  JobStatus
+	Done    chan struct{}
+	Stopped chan struct{}
+}
+
+type jobTableT struct {
+	mu         sync.Mutex
+	jobs       map[int]*Job
+	nextID     int
+	foreground int
+}
+
+var jobTable = &jobTableT{jobs: make(map[int]*Job), nextID: 1}
+
+func (jt *jobTableT) add(job *Job) {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	job.ID = jt.nextID
+	jt.nextID++
+	jt.jobs[job.ID] = job
+}
+
+func (jt *jobTableT) get(id int) (*Job, bool) {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	job, ok := jt.jobs[id]
+	return job, ok
+}
+
+func (jt *jobTableT) remove(id int) {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	delete(jt.jobs, id)
+}
+
+func (jt *jobTableT) list() []*Job {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	result := make([]*Job, 0, len(jt.jobs))
+	for _, job := range jt.jobs {
+		result = append(result, job)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// status and setStatus are the only safe way to read or write a Job's
+// Status: the job table's mutex, not whatever lock the caller happens to be
+// holding, is what actually protects that field against the cleanup
+// goroutine in executePipeline and the SIGTSTP handler in markStopped.
+func (jt *jobTableT) status(id int) JobStatus {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	if job, ok := jt.jobs[id]; ok {
+		return job.Status
+	}
+	return JobDone
+}
+
+func (jt *jobTableT) setStatus(id int, status JobStatus) {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	if job, ok := jt.jobs[id]; ok {
+		job.Status = status
+	}
+}
+
+func (jt *jobTableT) setForeground(id int) {
+	jt.mu.Lock()
+	jt.foreground = id
+	jt.mu.Unlock()
+}
+
+func (jt *jobTableT) foregroundJob() *Job {
+	jt.mu.Lock()
+	id := jt.foreground
+	jt.mu.Unlock()
+	if id == 0 {
+		return nil
+	}
+	job, _ := jt.get(id)
+	return job
+}
+
+func (jt *jobTableT) markStopped(id int) {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	if job, ok := jt.jobs[id]; ok && job.Status != JobStopped {
+		job.Status = JobStopped
+		close(job.Stopped)
+	}
+	if jt.foreground == id {
+		jt.foreground = 0
+	}
+}
+
+// installSignalForwarding catches SIGINT/SIGTSTP so the shell itself never
+// dies from them, and forwards each to the foreground pipeline's process
+// group instead. Ctrl-Z additionally marks that job Stopped and moves it into
+// the job table so `fg`/`bg` can resume it later.
+func installSignalForwarding() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTSTP)
+	go func() {
+		for sig := range sigCh {
+			job := jobTable.foregroundJob()
+			if job == nil || job.PGID == 0 {
+				continue
+			}
+			switch sig {
+			case syscall.SIGINT:
+				syscall.Kill(-job.PGID, syscall.SIGINT)
+			case syscall.SIGTSTP:
+				syscall.Kill(-job.PGID, syscall.SIGTSTP)
+				jobTable.markStopped(job.ID)
+			}
+		}
+	}()
+}
+
+// executePipeline wires the stages of a pipeline together and runs them
+// concurrently. Adjacent external commands are connected with an os.Pipe so
+// data never has to cross into the shell's own process; a stage next to a
+// builtin is connected with an in-process io.Pipe instead, since builtins
+// read/write through Go io.Reader/io.Writer values rather than file
+// descriptors.
+func executePipeline(stages []*Stage, background bool, raw string) {
+	n := len(stages)
+	stdins := make([]io.Reader, n)
+	stdouts := make([]io.Writer, n)
+	ownedIn := make([]bool, n)
+	ownedOut := make([]bool, n)
+	var toClose []io.Closer
+
+	stdins[0] = os.Stdin
+	stdouts[n-1] = os.Stdout
+
+	for i := 0; i < n-1; i++ {
+		left, right := stages[i].Fields[0], stages[i+1].Fields[0]
+		if !isBuiltinStage(left) && !isBuiltinStage(right) {
+			pr, pw, err := os.Pipe()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "pipe:", err)
+				return
+			}
+			stdouts[i], ownedOut[i] = pw, true
+			stdins[i+1], ownedIn[i+1] = pr, true
+		} else {
+			pr, pw := io.Pipe()
+			stdouts[i] = pw
+			stdins[i+1] = pr
+		}
+	}
+
+	job := &Job{Command: raw, Status: JobRunning, Done: make(chan struct{}), Stopped: make(chan struct{})}
+	jobTable.add(job)
+
+	var wg sync.WaitGroup
+	for i, stage := range stages {
+		last := i == n-1
+		stdout := stdouts[i]
+		if stage.StdoutFile != "" {
+			f, err := openFile(stage.StdoutFile, stage.StdoutAppend)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error opening file for stdout redirection:", err)
+				continue
+			}
+
+			// The stage now writes to the file, not the pipe set up for it
+			// above, so nothing will ever write to that pipe's other end.
+			// Close our side now or the next stage blocks forever on EOF.
+			if !last {
+				if wc, ok := stdouts[i].(io.Closer); ok {
+					wc.Close()
+				}
+			}
+
+			stdout = f
+			toClose = append(toClose, f)
+		}
+
+		stderr := io.Writer(os.Stderr)
+		if stage.StderrFile != "" {
+			f, err := openFile(stage.StderrFile, stage.StderrAppend)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error opening file for stderr redirection:", err)
+				continue
+			}
+			stderr = f
+			toClose = append(toClose, f)
+		}
+
+		if handler, ok := builtins[stage.Fields[0]]; ok {
+			wg.Add(1)
+			go func(handler BuiltinFunc, fields []string, stdin io.Reader, stdout, stderr io.Writer, last bool) {
+				defer wg.Done()
+				handler(&CMD{Name: fields[0], Args: fields, Stdin: stdin, Stdout: stdout, Stderr: stderr})
+				if !last {
+					if wc, ok := stdout.(io.WriteCloser); ok {
+						wc.Close()
+					}
+				}
+			}(handler, stage.Fields, stdins[i], stdout, stderr, last)
+			continue
+		}
+
+		path, err := exec.LookPath(stage.Fields[0])
+		if err != nil {
+			outputError(stage.Fields[0], stage.StderrFile, stage.StderrAppend)
+			if !last {
+				if wc, ok := stdout.(io.WriteCloser); ok {
+					wc.Close()
+				}
+			}
+			continue
+		}
+
+		c := exec.Command(path, stage.Fields[1:]...)
+		c.Stdin = stdins[i]
+		c.Stdout = stdout
+		c.Stderr = stderr
+		c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: job.PGID}
+
+		if err := c.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", stage.Fields[0], err)
+			continue
+		}
+		if job.PGID == 0 {
+			job.PGID = c.Process.Pid
+		}
+		job.Cmds = append(job.Cmds, c)
+
+		// Drop our copy of any pipe end the child now holds, so EOF
+		// propagates correctly down the chain once the child exits.
+		if stage.StdoutFile == "" && ownedOut[i] {
+			stdout.(*os.File).Close()
+		}
+		if ownedIn[i] {
+			stdins[i].(*os.File).Close()
+		}
+
+		wg.Add(1)
+		go func(c *exec.Cmd) {
+			defer wg.Done()
+			c.Wait()
+		}(c)
+	}
+
+	go func() {
+		wg.Wait()
+		jobTable.setStatus(job.ID, JobDone)
+		close(job.Done)
+		for _, c := range toClose {
+			c.Close()
+		}
+		if background {
+			fmt.Printf("\n[%d]+  Done                    %s\n", job.ID, job.Command)
+		}
+		jobTable.remove(job.ID)
+	}()
+
+	if background {
+		fmt.Printf("[%d] %d\n", job.ID, job.PGID)
+		return
+	}
+
+	jobTable.setForeground(job.ID)
+	select {
+	case <-job.Done:
+	case <-job.Stopped:
+		fmt.Printf("\n[%d]+  Stopped                 %s\n", job.ID, job.Command)
+	}
+	jobTable.setForeground(0)
+}
+
+func jobsHandler(cmd *CMD) {
+	for _, job := range jobTable.list() {
+		fmt.Fprintf(cmd.Stdout, "[%d]  %-10s %s\n", job.ID, jobTable.status(job.ID), job.Command)
+	}
+}
+
+func resolveJobArg(args []string) (*Job, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("missing job id")
+	}
+	spec := strings.TrimPrefix(args[1], "%")
+	id, err := strconv.Atoi(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job id: %s", args[1])
+	}
+	job, ok := jobTable.get(id)
+	if !ok {
+		return nil, fmt.Errorf("no such job: %s", args[1])
+	}
+	return job, nil
+}
+
+func fgHandler(cmd *CMD) {
+	job, err := resolveJobArg(cmd.Args)
+	if err != nil {
+		fmt.Fprintln(cmd.Stderr, "fg:", err)
+		return
+	}
+
+	fmt.Fprintln(cmd.Stdout, job.Command)
+	if jobTable.status(job.ID) == JobStopped {
+		jobTable.mu.Lock()
+		job.Status = JobRunning
+		job.Stopped = make(chan struct{})
+		jobTable.mu.Unlock()
+		syscall.Kill(-job.PGID, syscall.SIGCONT)
+	}
+
+	jobTable.setForeground(job.ID)
+	select {
+	case <-job.Done:
+	case <-job.Stopped:
+		fmt.Fprintf(cmd.Stdout, "\n[%d]+  Stopped                 %s\n", job.ID, job.Command)
+	}
+	jobTable.setForeground(0)
+}
+
+func bgHandler(cmd *CMD) {
+	job, err := resolveJobArg(cmd.Args)
+	if err != nil {
+		fmt.Fprintln(cmd.Stderr, "bg:", err)
+		return
+	}
+	if jobTable.status(job.ID) != JobStopped {
+		fmt.Fprintf(cmd.Stderr, "bg: job %d is already running\n", job.ID)
+		return
+	}
+
+	jobTable.mu.Lock()
+	job.Status = JobRunning
+	job.Stopped = make(chan struct{})
+	jobTable.mu.Unlock()
+	syscall.Kill(-job.PGID, syscall.SIGCONT)
+	fmt.Fprintf(cmd.Stdout, "[%d]+ %s &\n", job.ID, job.Command)
+}
+
+func waitHandler(cmd *CMD) {
+	if len(cmd.Args) > 1 {
+		job, err := resolveJobArg(cmd.Args)
+		if err != nil {
+			fmt.Fprintln(cmd.Stderr, "wait:", err)
+			return
+		}
+		<-job.Done
+		return
+	}
+	for _, job := range jobTable.list() {
+		<-job.Done
+	}
+}
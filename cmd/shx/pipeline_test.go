@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// captureStdout redirects the package-level os.Stdout for the duration of fn
+// and returns everything written to it. fn must not leave background work
+// that writes to stdout after it returns.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+
+	var buf bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(copied)
+	}()
+
+	fn()
+
+	os.Stdout = orig
+	w.Close()
+	<-copied
+	return buf.String()
+}
+
+// findJob locates the job most recently added under the given command, so
+// tests aren't thrown off by jobs other tests haven't finished cleaning up
+// yet.
+func findJob(t *testing.T, command string) *Job {
+	t.Helper()
+	jobs := jobTable.list()
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if jobs[i].Command == command {
+			return jobs[i]
+		}
+	}
+	t.Fatalf("no job found for command %q", command)
+	return nil
+}
+
+func runPipeline(t *testing.T, stages []*Stage) string {
+	t.Helper()
+	done := make(chan struct{})
+	var out string
+	out = captureStdout(t, func() {
+		go func() {
+			executePipeline(stages, false, "")
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("executePipeline did not return, pipeline likely hung")
+		}
+	})
+	return out
+}
+
+func TestExecutePipelineBuiltinToBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stages := []*Stage{
+		{Fields: []string{"ls", dir}},
+		{Fields: []string{"cat"}},
+	}
+
+	out := runPipeline(t, stages)
+	if !strings.Contains(out, "a.txt") {
+		t.Fatalf("expected ls's listing to flow through cat, got %q", out)
+	}
+}
+
+func TestExecutePipelineStdoutRedirectToFile(t *testing.T) {
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "out.txt")
+
+	stages := []*Stage{
+		{Fields: []string{"echo", "hi"}},
+		{Fields: []string{"cat"}, StdoutFile: outFile},
+	}
+
+	runPipeline(t, stages)
+
+	content, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("out.txt was not created: %v", err)
+	}
+	if strings.TrimSpace(string(content)) != "hi" {
+		t.Fatalf("unexpected out.txt content: %q", content)
+	}
+}
+
+// TestExecutePipelineStdoutFileRedirectOnNonFinalStageDoesNotHang is a
+// regression test for the orphaned os.Pipe writer: a non-final stage that
+// redirects its own stdout to a file used to leave the pipe feeding the next
+// stage open forever, so the next stage's read never saw EOF.
+func TestExecutePipelineStdoutFileRedirectOnNonFinalStageDoesNotHang(t *testing.T) {
+	dir := t.TempDir()
+	midFile := filepath.Join(dir, "mid.txt")
+
+	stages := []*Stage{
+		{Fields: []string{"printf", "hi"}, StdoutFile: midFile},
+		{Fields: []string{"wc", "-l"}},
+	}
+
+	out := runPipeline(t, stages)
+
+	midContent, err := os.ReadFile(midFile)
+	if err != nil {
+		t.Fatalf("mid.txt was not created: %v", err)
+	}
+	if string(midContent) != "hi" {
+		t.Fatalf("expected mid.txt to contain %q, got %q", "hi", midContent)
+	}
+	if strings.TrimSpace(out) != "0" {
+		t.Fatalf("expected wc to see an empty, closed pipe (0 lines), got %q", out)
+	}
+}
+
+func TestExecutePipelineBackgroundJobAppearsInJobs(t *testing.T) {
+	stages := []*Stage{{Fields: []string{"sleep", "0.3"}}}
+	executePipeline(stages, true, "sleep 0.3 &")
+
+	job := findJob(t, "sleep 0.3 &")
+
+	var buf bytes.Buffer
+	jobsHandler(&CMD{Stdout: &buf})
+	if !strings.Contains(buf.String(), "Running") || !strings.Contains(buf.String(), "sleep 0.3") {
+		t.Fatalf("jobs output missing the backgrounded job: %q", buf.String())
+	}
+
+	select {
+	case <-job.Done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("background job never finished")
+	}
+
+	// job.Done closes slightly before the cleanup goroutine removes the job
+	// from the table, so give it a moment to catch up.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := jobTable.get(job.ID); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job table still holds the job after it finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSIGINTInterruptsOnlyForegroundJob mirrors pressing Ctrl-C while a
+// pipeline is running in the foreground: it should kill that job's process
+// group without touching an unrelated backgrounded job.
+func TestSIGINTInterruptsOnlyForegroundJob(t *testing.T) {
+	var once sync.Once
+	once.Do(installSignalForwarding)
+
+	bgStages := []*Stage{{Fields: []string{"sleep", "2"}}}
+	executePipeline(bgStages, true, "sleep 2 &")
+	bgJob := findJob(t, "sleep 2 &")
+
+	fgDone := make(chan struct{})
+	go func() {
+		fgStages := []*Stage{{Fields: []string{"sleep", "2"}}}
+		executePipeline(fgStages, false, "sleep 2")
+		close(fgDone)
+	}()
+
+	// Give the foreground pipeline time to start its process and register
+	// itself as the foreground job before the signal is sent.
+	time.Sleep(200 * time.Millisecond)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatalf("failed to send SIGINT: %v", err)
+	}
+
+	select {
+	case <-fgDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("foreground pipeline did not stop after SIGINT")
+	}
+
+	select {
+	case <-bgJob.Done:
+		t.Fatal("background job was killed by a signal meant for the foreground job")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	<-bgJob.Done
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// reverseIncrementalSearch implements Ctrl-R: a mini-prompt that narrows a
+// match against historyList from newest to oldest on every keystroke. It
+// reads directly from the same raw-mode reader as the main input loop, since
+// it needs to classify the same escape sequences (Ctrl-G, Esc) mid-search.
+// Returns (original, false) on abort, or (matched line, true) on Enter.
+func reverseIncrementalSearch(r *bufio.Reader, original string) (string, bool) {
+	query := ""
+	matchIdx := len(historyList) - 1
+	match := ""
+
+	search := func(from int) bool {
+		for i := from; i >= 0; i-- {
+			if strings.Contains(historyList[i], query) {
+				matchIdx = i
+				match = historyList[i]
+				return true
+			}
+		}
+		return false
+	}
+
+	render := func() {
+		fmt.Fprintf(os.Stdout, "\r\x1b[K(reverse-i-search)'%s': %s", query, match)
+	}
+	render()
+
+	for {
+		rn, _, err := r.ReadRune()
+		if err != nil {
+			return original, false
+		}
+
+		switch rn {
+		case '\x12': // Ctrl-R: keep looking further back for the same query
+			if matchIdx > 0 {
+				search(matchIdx - 1)
+			} else {
+				fmt.Fprint(os.Stdout, "\a")
+			}
+			render()
+		case '\x07', '\x1b': // Ctrl-G or Esc: abort, restore original line
+			fmt.Fprint(os.Stdout, "\r\x1b[K")
+			return original, false
+		case '\r', '\n':
+			fmt.Fprint(os.Stdout, "\r\x1b[K")
+			return match, true
+		case '\x7F':
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = ""
+				search(len(historyList) - 1)
+			}
+			render()
+		default:
+			query += string(rn)
+			if !search(len(historyList) - 1) {
+				match = ""
+			}
+			render()
+		}
+	}
+}
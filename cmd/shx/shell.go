@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -26,16 +26,63 @@ var builtinCMDs = []string{
 	"rm",
 	"mkdir",
 	"rmdir",
+	"jobs",
+	"fg",
+	"bg",
+	"wait",
+	"history",
+	"ai",
 }
 
+// CMD describes one pipeline stage's invocation. Builtins read from Stdin and
+// write to Stdout/Stderr instead of touching the process-wide os.Stdout /
+// os.Stderr, so they behave correctly when run as part of a pipeline.
 type CMD struct {
 	Name   string
 	Args   []string
+	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
 }
 
+// BuiltinFunc is the signature every builtin handler implements.
+type BuiltinFunc func(cmd *CMD)
+
+// builtins is populated in init() rather than as a composite literal: "ai"
+// reaches back into executePipeline (ai -> runAIQuery -> offerToRun ->
+// runSuggestedCommand -> executePipeline -> builtins), and a package-level
+// var whose initializer transitively references itself is a compile error.
+var builtins map[string]BuiltinFunc
+
+func init() {
+	builtins = map[string]BuiltinFunc{
+		"exit":    exitHandler,
+		"echo":    echoHandler,
+		"type":    typeHandler,
+		"pwd":     pwdHandler,
+		"cd":      cdHandler,
+		"clear":   clearHandler,
+		"ls":      lsHandler,
+		"cat":     catHandler,
+		"cp":      cpHandler,
+		"mv":      mvHandler,
+		"rm":      rmHandler,
+		"mkdir":   mkdirHandler,
+		"rmdir":   rmdirHandler,
+		"jobs":    jobsHandler,
+		"fg":      fgHandler,
+		"bg":      bgHandler,
+		"wait":    waitHandler,
+		"history": historyHandler,
+		"ai":      aiHandler,
+	}
+}
+
 func main() {
+	initAI()
+	installSignalForwarding()
+	historyList = loadHistory()
+
 	for {
 		printPrompt()
 		input := readInputWithAutocomplete(os.Stdin)
@@ -44,37 +91,40 @@ func main() {
 			continue
 		}
 
-		fields := parseCommand(input)
-		if len(fields) == 0 {
-			continue
+		if strings.HasPrefix(input, "?") {
+			input = "ai " + strings.TrimSpace(strings.TrimPrefix(input, "?"))
 		}
 
-		fields, stdoutFile, stderrFile, stdoutAppend, stderrAppend := processRedirectionOperators(fields)
+		fields := parseCommand(input)
 		if len(fields) == 0 {
 			continue
 		}
 
-		builtins := map[string]func([]string){
-			"exit":  exitHandler,
-			"echo":  echoHandler,
-			"type":  typeHandler,
-			"pwd":   pwdHandler,
-			"cd":    cdHandler,
-			"clear": clearHandler,
-			"ls":    lsHandler,
-			"cat":   catHandler,
-			"cp":    cpHandler,
-			"mv":    mvHandler,
-			"rm":    rmHandler,
-			"mkdir": mkdirHandler,
-			"rmdir": rmdirHandler,
-		}
+		appendHistory(input)
 
-		if handler, exists := builtins[fields[0]]; exists {
-			executeBuiltinWithRedirection(handler, fields, stdoutFile, stderrFile, stdoutAppend, stderrAppend)
-		} else {
-			executeExternalWithRedirection(fields, stdoutFile, stderrFile, stdoutAppend, stderrAppend)
+		rawStages, background := splitPipeline(fields)
+
+		var stages []*Stage
+		valid := true
+		for _, rf := range rawStages {
+			sfields, stdoutFile, stderrFile, stdoutAppend, stderrAppend := processRedirectionOperators(rf)
+			if len(sfields) == 0 {
+				valid = false
+				break
+			}
+			stages = append(stages, &Stage{
+				Fields:       sfields,
+				StdoutFile:   stdoutFile,
+				StderrFile:   stderrFile,
+				StdoutAppend: stdoutAppend,
+				StderrAppend: stderrAppend,
+			})
+		}
+		if !valid || len(stages) == 0 {
+			continue
 		}
+
+		executePipeline(stages, background, input)
 	}
 }
 
@@ -92,6 +142,8 @@ func readInputWithAutocomplete(rd *os.File) string {
 	}
 	defer term.Restore(int(rd.Fd()), oldState)
 
+	resetHistoryBrowsing()
+
 	var input string
 	r := bufio.NewReader(rd)
 	for {
@@ -111,6 +163,36 @@ func readInputWithAutocomplete(rd *os.File) string {
 			tabPressCount = 0
 			lastTabPrefix = ""
 			return input
+		case '\x12':
+			result, ok := reverseIncrementalSearch(r, input)
+			if ok {
+				fmt.Fprint(os.Stdout, "\r\n")
+				tabPressCount = 0
+				lastTabPrefix = ""
+				return result
+			}
+			input = result
+			printPromptWithInput(input)
+		case '\x1b':
+			next, _, err := r.ReadRune()
+			if err != nil || next != '[' {
+				continue
+			}
+			next, _, err = r.ReadRune()
+			if err != nil {
+				continue
+			}
+			switch next {
+			case 'A':
+				input = historyUp(input)
+			case 'B':
+				input = historyDown()
+			default:
+				continue
+			}
+			tabPressCount = 0
+			lastTabPrefix = ""
+			printPromptWithInput(input)
 		case '\x7F':
 			if len(input) > 0 {
 				input = input[:len(input)-1]
@@ -182,7 +264,7 @@ func autocomplete(prefix string, tabCount int) (string, []string) {
 
 	if len(matches) == 0 {
 		pathEnv := os.Getenv("PATH")
-		dirs := append([]string{"."}, strings.Split(pathEnv, ":")...)
+		dirs := append([]string{"."}, strings.Split(pathEnv, string(filepath.ListSeparator))...)
 		found := make(map[string]bool)
 		for _, dir := range dirs {
 			files, err := os.ReadDir(dir)
@@ -292,98 +374,6 @@ func processRedirectionOperators(fields []string) ([]string, string, string, boo
 	return finalFields, stdoutFile, stderrFile, stdoutAppend, stderrAppend
 }
 
-func executeBuiltinWithRedirection(
-	handler func([]string),
-	args []string,
-	stdoutFile, stderrFile string,
-	stdoutAppend, stderrAppend bool,
-) {
-	oldStdout := os.Stdout
-	oldStderr := os.Stderr
-
-	if stdoutFile != "" {
-		file, err := openFile(stdoutFile, stdoutAppend)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error opening file for stdout redirection:", err)
-			return
-		}
-		os.Stdout = file
-		defer func() {
-			os.Stdout = oldStdout
-			file.Close()
-		}()
-	}
-
-	if stderrFile != "" {
-		file, err := openFile(stderrFile, stderrAppend)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error opening file for stderr redirection:", err)
-			return
-		}
-		os.Stderr = file
-		defer func() {
-			os.Stderr = oldStderr
-			file.Close()
-		}()
-	}
-
-	handler(args)
-}
-
-func executeExternalWithRedirection(
-	fields []string,
-	stdoutFile, stderrFile string,
-	stdoutAppend, stderrAppend bool,
-) {
-	if stdoutFile == "" && stderrFile == "" {
-		executeCommand(fields)
-		return
-	}
-
-	path, err := exec.LookPath(fields[0])
-	if err != nil {
-		outputError(fields[0], stderrFile, stderrAppend)
-		return
-	}
-
-	cmd := exec.Command(path, fields[1:]...)
-
-	if stdoutFile != "" {
-		if file, err := openFile(stdoutFile, stdoutAppend); err != nil {
-			fmt.Fprintln(os.Stderr, "Error opening file for stdout redirection:", err)
-			return
-		} else {
-			cmd.Stdout = file
-			defer file.Close()
-		}
-	} else {
-		cmd.Stdout = os.Stdout
-	}
-
-	if stderrFile != "" {
-		if file, err := openFile(stderrFile, stderrAppend); err != nil {
-			fmt.Fprintln(os.Stderr, "Error opening file for stderr redirection:", err)
-			return
-		} else {
-			cmd.Stderr = file
-			defer file.Close()
-		}
-	} else {
-		cmd.Stderr = os.Stderr
-	}
-
-	cmd.Run()
-}
-
-func executeCommand(fields []string) {
-	cmd := exec.Command(fields[0], fields[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Println(fields[0] + ": command not found")
-	}
-}
-
 func openFile(fileName string, appendMode bool) (*os.File, error) {
 	if appendMode {
 		return os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -406,6 +396,8 @@ func outputError(cmdName, stderrFile string, appendMode bool) {
 }
 
 func parseCommand(command string) []string {
+	command = expandHistoryReferences(command)
+
 	var result []string
 	var current strings.Builder
 	inSingleQuote, inDoubleQuote, escaped := false, false, false
@@ -461,177 +453,3 @@ func parseCommand(command string) []string {
 
 	return result
 }
-
-func exitHandler(args []string) {
-	os.Exit(0)
-}
-
-func echoHandler(args []string) {
-	fmt.Println(strings.Join(args[1:], " "))
-}
-
-func typeHandler(args []string) {
-	if len(args) < 2 {
-		fmt.Println("type: missing argument")
-		return
-	}
-	cmd := args[1]
-	builtins := map[string]bool{
-		"echo": true,
-		"exit": true,
-		"type": true,
-		"pwd":  true,
-		"cd":   true,
-	}
-
-	if builtins[cmd] {
-		fmt.Println(cmd + " is a shell builtin")
-	} else if path, err := exec.LookPath(cmd); err == nil {
-		fmt.Println(cmd + " is " + path)
-	} else {
-		fmt.Println(cmd + ": not found")
-	}
-}
-
-func pwdHandler(args []string) {
-	cwd, _ := os.Getwd()
-	fmt.Println(cwd)
-}
-
-func cdHandler(args []string) {
-	if len(args) < 2 {
-		fmt.Println("cd: missing argument")
-		return
-	}
-
-	dir := args[1]
-	switch {
-	case dir == "~":
-		dir = os.Getenv("HOME")
-	case strings.HasPrefix(dir, "~/"):
-		dir = os.Getenv("HOME") + dir[1:]
-	}
-
-	if err := os.Chdir(dir); err != nil {
-		fmt.Printf("cd: %s: No such file or directory\n", dir)
-	}
-}
-
-func clearHandler(args []string) {
-	cmd := exec.Command("cmd", "/c", "cls")
-	cmd.Stdout = os.Stdout
-	cmd.Run()
-}
-
-func lsHandler(args []string) {
-	dir := "."
-	if len(args) > 1 {
-		dir = args[1]
-	}
-
-	files, err := os.ReadDir(dir)
-	if err != nil {
-		fmt.Printf("ls: cannot access '%s': %v\n", dir, err)
-		return
-	}
-
-	for _, file := range files {
-		fmt.Println(file.Name())
-	}
-}
-
-func catHandler(args []string) {
-	if len(args) < 2 {
-		fmt.Println("cat: missing file operand")
-		return
-	}
-
-	for _, file := range args[1:] {
-		content, err := os.ReadFile(file)
-		if err != nil {
-			fmt.Printf("cat: %s: %v\n", file, err)
-			continue
-		}
-		fmt.Print(string(content))
-	}
-}
-
-func cpHandler(args []string) {
-	if len(args) < 3 {
-		fmt.Println("cp: missing file operand")
-		return
-	}
-
-	src := args[1]
-	dst := args[2]
-
-	srcFile, err := os.ReadFile(src)
-	if err != nil {
-		fmt.Printf("cp: cannot stat '%s': %v\n", src, err)
-		return
-	}
-
-	err = os.WriteFile(dst, srcFile, 0644)
-	if err != nil {
-		fmt.Printf("cp: cannot create '%s': %v\n", dst, err)
-		return
-	}
-}
-
-func mvHandler(args []string) {
-	if len(args) < 3 {
-		fmt.Println("mv: missing file operand")
-		return
-	}
-
-	src := args[1]
-	dst := args[2]
-
-	err := os.Rename(src, dst)
-	if err != nil {
-		fmt.Printf("mv: cannot move '%s' to '%s': %v\n", src, dst, err)
-		return
-	}
-}
-
-func rmHandler(args []string) {
-	if len(args) < 2 {
-		fmt.Println("rm: missing operand")
-		return
-	}
-
-	for _, file := range args[1:] {
-		err := os.Remove(file)
-		if err != nil {
-			fmt.Printf("rm: cannot remove '%s': %v\n", file, err)
-		}
-	}
-}
-
-func mkdirHandler(args []string) {
-	if len(args) < 2 {
-		fmt.Println("mkdir: missing operand")
-		return
-	}
-
-	for _, dir := range args[1:] {
-		err := os.MkdirAll(dir, 0755)
-		if err != nil {
-			fmt.Printf("mkdir: cannot create directory '%s': %v\n", dir, err)
-		}
-	}
-}
-
-func rmdirHandler(args []string) {
-	if len(args) < 2 {
-		fmt.Println("rmdir: missing operand")
-		return
-	}
-
-	for _, dir := range args[1:] {
-		err := os.Remove(dir)
-		if err != nil {
-			fmt.Printf("rmdir: failed to remove '%s': %v\n", dir, err)
-		}
-	}
-}
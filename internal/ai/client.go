@@ -0,0 +1,206 @@
+// Package ai holds the Gemini client shared by the ShX "ai" builtin and the
+// standalone HTTP server, so both talk to Gemini through one request shape,
+// one retry/backoff policy, and one place that reads GEMINI_API_KEY.
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	generateURL = "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-pro:generateContent"
+	streamURL   = "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-pro:streamGenerateContent"
+
+	maxRetries = 3
+	retryDelay = 500 * time.Millisecond
+)
+
+type requestPayload struct {
+	Contents []struct {
+		Parts []struct {
+			Text string `json:"text"`
+		} `json:"parts"`
+	} `json:"contents"`
+}
+
+type responsePayload struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+}
+
+// Delta is one incremental chunk of a streamed Gemini reply.
+type Delta struct {
+	Text string
+	Err  error
+}
+
+// Client talks to the Gemini generateContent API, either blocking for the
+// full reply or streaming deltas.
+type Client struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient reads GEMINI_API_KEY from the environment. Callers should check
+// the error at startup rather than discovering a missing key on first use.
+func NewClient() (*Client, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+	}
+	return &Client{apiKey: apiKey, httpClient: http.DefaultClient}, nil
+}
+
+func buildPayload(query string) requestPayload {
+	var payload requestPayload
+	payload.Contents = []struct {
+		Parts []struct {
+			Text string `json:"text"`
+		} `json:"parts"`
+	}{
+		{
+			Parts: []struct {
+				Text string `json:"text"`
+			}{
+				{Text: query},
+			},
+		},
+	}
+	return payload
+}
+
+// Generate blocks until the full Gemini reply is available, retrying
+// transient request failures with a short fixed backoff.
+func (c *Client) Generate(ctx context.Context, query string) (string, error) {
+	jsonPayload, err := json.Marshal(buildPayload(query))
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s?key=%s", generateURL, c.apiKey)
+
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonPayload))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("gemini: %s", resp.Status)
+			continue
+		}
+
+		var response responsePayload
+		if err := json.Unmarshal(body, &response); err != nil {
+			return "", err
+		}
+		if len(response.Candidates) > 0 && len(response.Candidates[0].Content.Parts) > 0 {
+			return response.Candidates[0].Content.Parts[0].Text, nil
+		}
+		return "No response from Gemini.", nil
+	}
+
+	return "", fmt.Errorf("gemini: request failed after %d attempts: %w", maxRetries, lastErr)
+}
+
+// GenerateStream hits streamGenerateContent with alt=sse and forwards each
+// candidate text delta on the returned channel as it arrives. The channel is
+// closed when the upstream stream ends or ctx is cancelled; a failure mid
+// stream is sent as a final Delta with Err set.
+func (c *Client) GenerateStream(ctx context.Context, query string) (<-chan Delta, error) {
+	jsonPayload, err := json.Marshal(buildPayload(query))
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s?alt=sse&key=%s", streamURL, c.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonPayload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := make(chan Delta)
+	go func() {
+		defer close(deltas)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "" || data == "[DONE]" {
+				continue
+			}
+
+			var chunk responsePayload
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				select {
+				case deltas <- Delta{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+
+			if len(chunk.Candidates) > 0 && len(chunk.Candidates[0].Content.Parts) > 0 {
+				select {
+				case deltas <- Delta{Text: chunk.Candidates[0].Content.Parts[0].Text}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			select {
+			case deltas <- Delta{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return deltas, nil
+}